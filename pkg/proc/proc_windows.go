@@ -1,26 +1,118 @@
 package proc
 
 import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
+	"unicode/utf16"
 	"unsafe"
 
 	sys "golang.org/x/sys/windows"
 )
 
+// Module describes a PE module (EXE or DLL) loaded into the debuggee's
+// address space.
+type Module struct {
+	Address uint64
+	Size    uint64
+	Path    string
+}
+
 // OSProcessDetails holds Windows specific information.
 type OSProcessDetails struct {
 	hProcess    syscall.Handle
 	breakThread int
+
+	// mu guards debugStrings, exceptionFilters, modules and
+	// moduleLoadErrors, which (unlike the rest of this struct) are read
+	// and written from outside the ptrace goroutine: a service/RPC layer
+	// polling DebugStrings or calling SetExceptionFilter runs
+	// concurrently with waitForDebugEvent. children and pendingChildren
+	// are not guarded by mu.
+	mu sync.Mutex
+
+	// debugStrings buffers OUTPUT_DEBUG_STRING_EVENT messages received
+	// from the debuggee that have not yet been consumed through
+	// Process.DebugStrings.
+	debugStrings []string
+
+	// exceptionFilters overrides the default stop policy for a given
+	// exception code, see Process.SetExceptionFilter.
+	exceptionFilters map[uint32]ExceptionMode
+
+	// children holds the Process objects for descendants traced
+	// alongside dbp because Launch was asked to follow children, keyed
+	// by PID. Only populated on the Process that was actually launched
+	// or attached to; descendants' own os.children are left nil.
+	children map[int]*Process
+
+	// pendingChildren holds children newly added to children by the
+	// current waitForDebugEvent call that still need their DWARF/PDB
+	// and breakpoint table set up by initPendingChildren, which must
+	// run outside the ptrace goroutine.
+	pendingChildren []*Process
+
+	// modules holds the DLLs currently loaded into this process, as
+	// reported by LOAD_DLL_DEBUG_EVENT; the main executable is not
+	// recorded here. See Process.Modules.
+	modules []*Module
+
+	// moduleLoadErrors buffers debug-info loading failures for modules
+	// in modules, see Process.ModuleLoadErrors.
+	moduleLoadErrors []error
+}
+
+// ExceptionMode controls whether waitForDebugEvent stops the target for
+// a given exception code, and on which chance, mirroring WinDbg's
+// sxe/sxd/sxi exception filters.
+type ExceptionMode uint8
+
+const (
+	// ExceptionModeIgnore passes the exception back to the debuggee
+	// (_DBG_EXCEPTION_NOT_HANDLED) without stopping. This is the
+	// default for every code except breakpoint and single-step traps,
+	// so benign SEH mechanisms like runtime.sigpanic or C++ EH
+	// (0xE06D7363) pass through undisturbed.
+	ExceptionModeIgnore ExceptionMode = iota
+	// ExceptionModeBreakFirstChance stops as soon as the exception is
+	// raised, before the debuggee's own handlers run.
+	ExceptionModeBreakFirstChance
+	// ExceptionModeBreakSecondChance stops only once no handler in the
+	// debuggee claimed the exception, i.e. when it is about to become
+	// fatal.
+	ExceptionModeBreakSecondChance
+)
+
+// SetExceptionFilter configures how waitForDebugEvent reacts when the
+// debuggee raises the given exception code, e.g. stopping on
+// EXCEPTION_ACCESS_VIOLATION before the runtime's own handler runs. This
+// is the hook the service/rpc2 layer calls for a client's sxe/sxd/sxi-style
+// request; routing it through JSON-RPC and DAP is that layer's job, not
+// this package's.
+func (dbp *Process) SetExceptionFilter(code uint32, mode ExceptionMode) error {
+	dbp.os.mu.Lock()
+	defer dbp.os.mu.Unlock()
+	if dbp.os.exceptionFilters == nil {
+		dbp.os.exceptionFilters = make(map[uint32]ExceptionMode)
+	}
+	dbp.os.exceptionFilters[code] = mode
+	return nil
 }
 
-// Launch creates and begins debugging a new process.
-func Launch(cmd []string, wd string) (*Process, error) {
+// Launch creates and begins debugging a new process. When followChildren
+// is set, any process the target spawns (not just its direct children,
+// since Windows reports the whole debuggee tree under DEBUG_PROCESS) is
+// attached to automatically instead of running free; this is needed to
+// debug Go programs that shell out to helpers or fork worker binaries.
+func Launch(cmd []string, wd string, followChildren bool) (*Process, error) {
 	argv0Go, err := filepath.Abs(cmd[0])
 	if err != nil {
 		return nil, err
@@ -93,12 +185,17 @@ func Launch(cmd []string, wd string) (*Process, error) {
 	si.StdErr = sys.Handle(fd[2])
 	pi := new(sys.ProcessInformation)
 
+	creationFlags := uint32(_DEBUG_ONLY_THIS_PROCESS)
+	if followChildren {
+		creationFlags = _DEBUG_PROCESS
+	}
+
 	dbp := New(0)
 	dbp.execPtraceFunc(func() {
 		if wd == "" {
-			err = sys.CreateProcess(argv0, cmdLine, nil, nil, true, _DEBUG_ONLY_THIS_PROCESS, nil, nil, si, pi)
+			err = sys.CreateProcess(argv0, cmdLine, nil, nil, true, creationFlags, nil, nil, si, pi)
 		} else {
-			err = sys.CreateProcess(argv0, cmdLine, nil, nil, true, _DEBUG_ONLY_THIS_PROCESS, nil, workingDir, si, pi)
+			err = sys.CreateProcess(argv0, cmdLine, nil, nil, true, creationFlags, nil, workingDir, si, pi)
 		}
 	})
 	if err != nil {
@@ -122,7 +219,7 @@ func newDebugProcess(dbp *Process, exepath string) (*Process, error) {
 	var err error
 	var tid, exitCode int
 	dbp.execPtraceFunc(func() {
-		tid, exitCode, err = dbp.waitForDebugEvent(waitBlocking)
+		_, tid, exitCode, err = dbp.waitForDebugEvent(waitBlocking)
 	})
 	if err != nil {
 		return nil, err
@@ -184,11 +281,379 @@ func findExePath(pid int) (string, error) {
 	}
 }
 
+// readDebugString reads the string carried by an OUTPUT_DEBUG_STRING_EVENT
+// out of the debuggee's address space, honoring fUnicode and
+// nDebugStringLength.
+func (dbp *Process) readDebugString(info *_OUTPUT_DEBUG_STRING_INFO) (string, error) {
+	n := int(info.NDebugStringLength)
+	if n <= 0 {
+		return "", nil
+	}
+	buf := make([]byte, n)
+	var read uintptr
+	if err := _ReadProcessMemory(dbp.os.hProcess, info.LpDebugStringData, &buf[0], uintptr(n), &read); err != nil {
+		return "", err
+	}
+	buf = buf[:read]
+	if info.FUnicode != 0 {
+		u16 := make([]uint16, len(buf)/2)
+		for i := range u16 {
+			u16[i] = uint16(buf[2*i]) | uint16(buf[2*i+1])<<8
+		}
+		return strings.TrimRight(string(utf16.Decode(u16)), "\x00"), nil
+	}
+	return strings.TrimRight(string(buf), "\x00"), nil
+}
+
+// DebugStrings returns the OutputDebugStringA/W messages the debuggee has
+// produced since the last call, and clears the buffer. Clients (CLI,
+// headless, DAP) poll this to show debug output live, the way Visual
+// Studio and WinDbg do.
+func (dbp *Process) DebugStrings() []string {
+	dbp.os.mu.Lock()
+	defer dbp.os.mu.Unlock()
+	s := dbp.os.debugStrings
+	dbp.os.debugStrings = nil
+	return s
+}
+
+// findFilePathFromHandle resolves the filesystem path backing an open
+// file handle, for the LOAD_DLL_DEBUG_EVENT case where lpImageName isn't
+// usable.
+func findFilePathFromHandle(h syscall.Handle) (string, error) {
+	if h == 0 || h == syscall.InvalidHandle {
+		return "", errors.New("no file handle for this module")
+	}
+	n := uint32(260)
+	for {
+		buf := make([]uint16, n)
+		r, err := sys.GetFinalPathNameByHandle(sys.Handle(h), &buf[0], n, 0)
+		if err != nil {
+			return "", err
+		}
+		if r > n {
+			n = r
+			continue
+		}
+		path := syscall.UTF16ToString(buf[:r])
+		return strings.TrimPrefix(path, `\\?\`), nil
+	}
+}
+
+// dllPath resolves the path of a DLL reported by a LOAD_DLL_DEBUG_EVENT.
+// lpImageName is frequently NULL, or points at a pointer the debuggee
+// hasn't initialized yet, so we fall back to resolving the path from the
+// file handle the kernel handed us for the load.
+func (dbp *Process) dllPath(debugInfo *_LOAD_DLL_DEBUG_INFO) (string, error) {
+	if debugInfo.LpImageName != 0 {
+		if s, err := dbp.readImageName(debugInfo.LpImageName, debugInfo.FUnicode != 0); err == nil && s != "" {
+			return s, nil
+		}
+	}
+	return findFilePathFromHandle(debugInfo.File)
+}
+
+// readImageName dereferences the pointer-to-pointer-to-string convention
+// Windows uses for lpImageName: addr points at a variable in the
+// debuggee that itself holds the address of the (possibly UTF-16)
+// image name.
+func (dbp *Process) readImageName(addr uintptr, unicode bool) (string, error) {
+	var strAddrBuf [unsafe.Sizeof(uintptr(0))]byte
+	var read uintptr
+	if err := _ReadProcessMemory(dbp.os.hProcess, addr, &strAddrBuf[0], uintptr(len(strAddrBuf)), &read); err != nil {
+		return "", err
+	}
+	strAddr := *(*uintptr)(unsafe.Pointer(&strAddrBuf[0]))
+	if strAddr == 0 {
+		return "", nil
+	}
+
+	charSize := 1
+	if unicode {
+		charSize = 2
+	}
+	buf := make([]byte, 0, 260*charSize)
+	chunk := make([]byte, charSize)
+	for len(buf) < 32*1024 {
+		if err := _ReadProcessMemory(dbp.os.hProcess, strAddr+uintptr(len(buf)), &chunk[0], uintptr(charSize), &read); err != nil {
+			return "", err
+		}
+		if allZero(chunk) {
+			break
+		}
+		buf = append(buf, chunk...)
+	}
+	if unicode {
+		u16 := make([]uint16, len(buf)/2)
+		for i := range u16 {
+			u16[i] = uint16(buf[2*i]) | uint16(buf[2*i+1])<<8
+		}
+		return string(utf16.Decode(u16)), nil
+	}
+	return string(buf), nil
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Modules returns the DLLs currently loaded into the debuggee's address
+// space. The main executable is not included.
+func (dbp *Process) Modules() []*Module {
+	dbp.os.mu.Lock()
+	defer dbp.os.mu.Unlock()
+	modules := make([]*Module, len(dbp.os.modules))
+	copy(modules, dbp.os.modules)
+	return modules
+}
+
+// ModuleLoadErrors returns, and clears, the debug-info loading errors
+// encountered for DLLs loaded so far. A module that has no usable
+// DWARF or PDB (stripped system DLLs, mostly) shouldn't abort the whole
+// debug session, so loadModule records the failure here instead of
+// discarding it.
+func (dbp *Process) ModuleLoadErrors() []error {
+	dbp.os.mu.Lock()
+	defer dbp.os.mu.Unlock()
+	e := dbp.os.moduleLoadErrors
+	dbp.os.moduleLoadErrors = nil
+	return e
+}
+
+// loadModule records a newly loaded DLL and makes its debug information
+// available for stack traces and breakpoint resolution, just like a Go
+// binary's own image. cgo and other natively-built DLLs are usually
+// produced by MSVC and carry a PDB rather than embedded DWARF, so a PDB
+// referenced by the module's CodeView debug directory is preferred;
+// AddImage's DWARF path (used by MinGW-built DLLs) is the fallback.
+func (dbp *Process) loadModule(path string, base uint64) {
+	size := peImageSize(path)
+
+	var loadErr error
+	if pdbPath, perr := findPDBPath(path); perr == nil && pdbPath != "" {
+		loadErr = dbp.bi.AddImageWithPDB(path, base, pdbPath)
+	} else {
+		loadErr = dbp.bi.AddImage(path, base)
+	}
+
+	dbp.os.mu.Lock()
+	dbp.os.modules = append(dbp.os.modules, &Module{Address: base, Size: size, Path: path})
+	if loadErr != nil {
+		dbp.os.moduleLoadErrors = append(dbp.os.moduleLoadErrors, fmt.Errorf("loading debug info for %s: %w", path, loadErr))
+	}
+	dbp.os.mu.Unlock()
+}
+
+// unloadModule removes the module whose base address matches base,
+// mirroring a LOAD_DLL_DEBUG_EVENT on UNLOAD_DLL_DEBUG_EVENT.
+func (dbp *Process) unloadModule(base uint64) {
+	dbp.os.mu.Lock()
+	defer dbp.os.mu.Unlock()
+	for i, m := range dbp.os.modules {
+		if m.Address == base {
+			dbp.os.modules = append(dbp.os.modules[:i], dbp.os.modules[i+1:]...)
+			return
+		}
+	}
+}
+
+// peImageSize reads SizeOfImage out of the PE optional header, falling
+// back to 0 (unknown) if the file can't be parsed, e.g. because it has
+// already been unloaded.
+func peImageSize(path string) uint64 {
+	f, err := pe.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return uint64(oh.SizeOfImage)
+	case *pe.OptionalHeader64:
+		return uint64(oh.SizeOfImage)
+	}
+	return 0
+}
+
+const (
+	_IMAGE_DIRECTORY_ENTRY_DEBUG = 6
+	_IMAGE_DEBUG_TYPE_CODEVIEW   = 2
+	_imageDebugDirectorySize     = 28
+)
+
+// findPDBPath extracts the PDB path an MSVC-built PE image records in
+// its CodeView debug directory entry (an "RSDS" record), returning ""
+// if the image has none (e.g. it was built with embedded DWARF instead).
+func findPDBPath(path string) (string, error) {
+	f, err := pe.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var rva, size uint32
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if len(oh.DataDirectory) <= _IMAGE_DIRECTORY_ENTRY_DEBUG {
+			return "", nil
+		}
+		dd := oh.DataDirectory[_IMAGE_DIRECTORY_ENTRY_DEBUG]
+		rva, size = dd.VirtualAddress, dd.Size
+	case *pe.OptionalHeader64:
+		if len(oh.DataDirectory) <= _IMAGE_DIRECTORY_ENTRY_DEBUG {
+			return "", nil
+		}
+		dd := oh.DataDirectory[_IMAGE_DIRECTORY_ENTRY_DEBUG]
+		rva, size = dd.VirtualAddress, dd.Size
+	default:
+		return "", nil
+	}
+	if rva == 0 || size == 0 {
+		return "", nil
+	}
+
+	dirOffset, ok := rvaToFileOffset(f, rva)
+	if !ok {
+		return "", nil
+	}
+	dir, err := readFileRange(path, dirOffset, size)
+	if err != nil {
+		return "", err
+	}
+
+	for off := 0; off+_imageDebugDirectorySize <= len(dir); off += _imageDebugDirectorySize {
+		entry := dir[off : off+_imageDebugDirectorySize]
+		if binary.LittleEndian.Uint32(entry[12:16]) != _IMAGE_DEBUG_TYPE_CODEVIEW {
+			continue
+		}
+		dataSize := binary.LittleEndian.Uint32(entry[16:20])
+		ptrToRawData := binary.LittleEndian.Uint32(entry[24:28])
+
+		cv, err := readFileRange(path, ptrToRawData, dataSize)
+		if err != nil || len(cv) < 24 || string(cv[0:4]) != "RSDS" {
+			continue
+		}
+		// RSDS signature (4 bytes) + GUID (16 bytes) + age (4 bytes),
+		// followed by a NUL-terminated path to the PDB.
+		name := cv[24:]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		return string(name), nil
+	}
+	return "", nil
+}
+
+// rvaToFileOffset maps a relative virtual address to its offset in the
+// PE file on disk, by finding the section that contains it.
+func rvaToFileOffset(f *pe.File, rva uint32) (uint32, bool) {
+	for _, s := range f.Sections {
+		if rva >= s.VirtualAddress && rva < s.VirtualAddress+s.VirtualSize {
+			return s.Offset + (rva - s.VirtualAddress), true
+		}
+	}
+	return 0, false
+}
+
+// readFileRange reads size bytes at offset from the file at path.
+func readFileRange(path string, offset, size uint32) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, size)
+	if _, err := f.ReadAt(buf, int64(offset)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// errNotAdmin is returned by Attach when DebugActiveProcess fails and the
+// calling process could not acquire SeDebugPrivilege, which almost always
+// means it isn't running
+// elevated; attaching to another user's process, or to any Windows
+// service, requires it.
+var errNotAdmin = errors.New("proc: could not acquire SeDebugPrivilege, is this process running as Administrator?")
+
+// acquireDebugPrivilege enables SeDebugPrivilege on the calling process's
+// token, which DebugActiveProcess needs to attach to processes not
+// already owned by the current user (e.g. Windows services).
+func acquireDebugPrivilege() error {
+	var token sys.Token
+	if err := sys.OpenProcessToken(sys.CurrentProcess(), sys.TOKEN_ADJUST_PRIVILEGES|sys.TOKEN_QUERY, &token); err != nil {
+		return fmt.Errorf("proc: OpenProcessToken: %w", err)
+	}
+	defer token.Close()
+
+	var luid sys.LUID
+	if err := sys.LookupPrivilegeValue(nil, sys.StringToUTF16Ptr("SeDebugPrivilege"), &luid); err != nil {
+		return fmt.Errorf("proc: LookupPrivilegeValue: %w", err)
+	}
+
+	privileges := sys.Tokenprivileges{
+		PrivilegeCount: 1,
+		Privileges: [1]sys.LUIDAndAttributes{
+			{Luid: luid, Attributes: sys.SE_PRIVILEGE_ENABLED},
+		},
+	}
+	switch err := sys.AdjustTokenPrivileges(token, false, &privileges, 0, nil, nil); err {
+	case nil:
+		return nil
+	case sys.ERROR_NOT_ALL_ASSIGNED, sys.ERROR_ACCESS_DENIED:
+		// These are the errors AdjustTokenPrivileges actually returns
+		// when the caller isn't allowed to enable the privilege, i.e.
+		// isn't running elevated.
+		return errNotAdmin
+	default:
+		return fmt.Errorf("proc: AdjustTokenPrivileges: %w", err)
+	}
+}
+
+// PidsByName returns the process IDs of all running processes whose
+// executable is named name (e.g. "myservice.exe"). This is useful for
+// attaching to targets like Windows services, whose PID changes on
+// every start; the CLI's `dlv attach` and an equivalent RPC call would
+// use this to resolve a name to the PID Attach actually takes.
+func PidsByName(name string) ([]int, error) {
+	snap, err := sys.CreateToolhelp32Snapshot(sys.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer sys.CloseHandle(snap)
+
+	var entry sys.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	var pids []int
+	for err = sys.Process32First(snap, &entry); err == nil; err = sys.Process32Next(snap, &entry) {
+		if strings.EqualFold(sys.UTF16ToString(entry.ExeFile[:]), name) {
+			pids = append(pids, int(entry.ProcessID))
+		}
+	}
+	if err != syscall.ERROR_NO_MORE_FILES {
+		return nil, err
+	}
+	return pids, nil
+}
+
 // Attach to an existing process with the given PID.
 func Attach(pid int) (*Process, error) {
-	// TODO: Probably should have SeDebugPrivilege before starting here.
-	err := _DebugActiveProcess(uint32(pid))
-	if err != nil {
+	// Enabling SeDebugPrivilege is only required to attach to a process
+	// we don't already own (another user's process, or a service); do
+	// it best-effort and let the DebugActiveProcess call below be the
+	// actual arbiter of whether the attach is allowed.
+	_ = acquireDebugPrivilege()
+
+	if err := _DebugActiveProcess(uint32(pid)); err != nil {
+		if privErr := acquireDebugPrivilege(); privErr != nil {
+			return nil, privErr
+		}
 		return nil, err
 	}
 	exepath, err := findExePath(pid)
@@ -214,6 +679,60 @@ func (dbp *Process) Kill() error {
 	return nil
 }
 
+// MinidumpKind selects which optional data streams dbghelp includes when
+// Process.WriteMinidump snapshots a debuggee.
+type MinidumpKind uint32
+
+const (
+	// MinidumpNormal captures just enough to unwind stacks.
+	MinidumpNormal MinidumpKind = 0
+	// MinidumpWithFullMemory includes the debuggee's entire address
+	// space, not just the stacks.
+	MinidumpWithFullMemory MinidumpKind = 1 << iota
+	// MinidumpWithHandleData includes the debuggee's handle table.
+	MinidumpWithHandleData
+	// MinidumpWithThreadInfo includes extra per-thread state beyond
+	// registers and stacks.
+	MinidumpWithThreadInfo
+)
+
+// toMiniDumpType translates a MinidumpKind into the MINIDUMP_TYPE flags
+// MiniDumpWriteDump expects.
+func (k MinidumpKind) toMiniDumpType() uint32 {
+	typ := uint32(_MiniDumpNormal)
+	if k&MinidumpWithFullMemory != 0 {
+		typ |= _MiniDumpWithFullMemory
+	}
+	if k&MinidumpWithHandleData != 0 {
+		typ |= _MiniDumpWithHandleData
+	}
+	if k&MinidumpWithThreadInfo != 0 {
+		typ |= _MiniDumpWithThreadInfo
+	}
+	return typ
+}
+
+// WriteMinidump snapshots the debuggee to path using dbghelp's
+// MiniDumpWriteDump, so it can be analyzed offline, including being
+// reopened by Delve's own core loader.
+func (dbp *Process) WriteMinidump(path string, kind MinidumpKind) error {
+	if !dbp.threads[dbp.pid].Stopped() {
+		return errors.New("process must be stopped to write a minidump")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var dumpErr error
+	dbp.execPtraceFunc(func() {
+		dumpErr = _MiniDumpWriteDump(dbp.os.hProcess, uint32(dbp.pid), sys.Handle(f.Fd()), kind.toMiniDumpType(), nil, nil, nil)
+	})
+	return dumpErr
+}
+
 func (dbp *Process) requestManualStop() error {
 	return _DebugBreakProcess(dbp.os.hProcess)
 }
@@ -251,6 +770,68 @@ func findExecutable(path string, pid int) string {
 	return path
 }
 
+// processByPID returns the Process representing pid: dbp itself, or one
+// of the descendants being traced alongside it because Launch was asked
+// to follow children. Returns nil if pid is unknown to dbp.
+func (dbp *Process) processByPID(pid int) *Process {
+	if pid == dbp.pid {
+		return dbp
+	}
+	return dbp.os.children[pid]
+}
+
+// processForThread returns the Process that owns thread: dbp itself, or
+// whichever followed child thread actually belongs to. Returns nil if
+// thread isn't known to any of them, which shouldn't happen for a thread
+// obtained from trapWait.
+func (dbp *Process) processForThread(thread *Thread) *Process {
+	if t, ok := dbp.threads[thread.ID]; ok && t == thread {
+		return dbp
+	}
+	for _, child := range dbp.os.children {
+		if t, ok := child.threads[thread.ID]; ok && t == thread {
+			return child
+		}
+	}
+	return nil
+}
+
+// initPendingChildren finishes initializing the children waitForDebugEvent
+// queued onto dbp.os.pendingChildren: resolving each one's executable path
+// and loading its DWARF/PDB and breakpoint table via initializeDebugProcess.
+// That does real file I/O, so — like newDebugProcess's own call to
+// initializeDebugProcess, which happens only after its execPtraceFunc call
+// has returned — this must only run outside the ptrace goroutine, never
+// from inside waitForDebugEvent itself.
+func (dbp *Process) initPendingChildren() error {
+	pending := dbp.os.pendingChildren
+	dbp.os.pendingChildren = nil
+	for _, child := range pending {
+		exepath, err := findExePath(child.pid)
+		if err != nil {
+			return err
+		}
+		if _, err := initializeDebugProcess(child, exepath, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Children returns the descendant processes currently being traced
+// alongside dbp (see the followChildren argument to Launch). Each one is
+// a fully initialized Process (DWARF/PDB loaded, own thread and
+// breakpoint state) that can be driven independently; it's the
+// service/rpc2 and DAP layers' job to list these and let a client switch
+// focus to one, which is out of scope for this package.
+func (dbp *Process) Children() []*Process {
+	children := make([]*Process, 0, len(dbp.os.children))
+	for _, child := range dbp.os.children {
+		children = append(children, child)
+	}
+	return children
+}
+
 type waitForDebugEventFlags int
 
 const (
@@ -258,7 +839,11 @@ const (
 	waitSuspendNewThreads
 )
 
-func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, exitCode int, err error) {
+// waitForDebugEvent waits for and dispatches debug events for dbp and,
+// when it is tracing children (see Launch's followChildren argument),
+// for any of the process IDs in dbp.os.children. pid identifies which
+// of those processes threadID belongs to.
+func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (pid, threadID, exitCode int, err error) {
 	var debugEvent _DEBUG_EVENT
 	shouldExit := false
 	for {
@@ -270,8 +855,9 @@ func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, e
 		// Wait for a debug event...
 		err := _WaitForDebugEvent(&debugEvent, milliseconds)
 		if err != nil {
-			return 0, 0, err
+			return 0, 0, 0, err
 		}
+		evtPid := int(debugEvent.ProcessId)
 
 		// ... handle each event kind ...
 		unionPtr := unsafe.Pointer(&debugEvent.U[0])
@@ -282,44 +868,93 @@ func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, e
 			if hFile != 0 && hFile != syscall.InvalidHandle {
 				err = syscall.CloseHandle(hFile)
 				if err != nil {
-					return 0, 0, err
+					return 0, 0, 0, err
+				}
+			}
+			target := dbp
+			if evtPid != dbp.pid {
+				// Under DEBUG_PROCESS this is a descendant the
+				// debuggee spawned; give it its own Process. Loading
+				// its DWARF/PDB and setting up its breakpoint table
+				// does real file I/O and must not happen on the
+				// ptrace goroutine we're running on here (the same
+				// reason newDebugProcess calls initializeDebugProcess
+				// only after its execPtraceFunc call returns), so we
+				// just record it and finish the job in
+				// initPendingChildren once back on the caller's
+				// goroutine.
+				target = New(evtPid)
+				if dbp.os.children == nil {
+					dbp.os.children = make(map[int]*Process)
 				}
+				dbp.os.children[evtPid] = target
+				dbp.os.pendingChildren = append(dbp.os.pendingChildren, target)
 			}
-			dbp.os.hProcess = debugInfo.Process
-			_, err = dbp.addThread(debugInfo.Thread, int(debugEvent.ThreadId), false, flags&waitSuspendNewThreads != 0)
+			target.os.hProcess = debugInfo.Process
+			_, err = target.addThread(debugInfo.Thread, int(debugEvent.ThreadId), false, flags&waitSuspendNewThreads != 0)
 			if err != nil {
-				return 0, 0, err
+				return 0, 0, 0, err
 			}
 			break
 		case _CREATE_THREAD_DEBUG_EVENT:
 			debugInfo := (*_CREATE_THREAD_DEBUG_INFO)(unionPtr)
-			_, err = dbp.addThread(debugInfo.Thread, int(debugEvent.ThreadId), false, flags&waitSuspendNewThreads != 0)
+			target := dbp.processByPID(evtPid)
+			if target == nil {
+				return 0, 0, 0, fmt.Errorf("CREATE_THREAD_DEBUG_EVENT for untracked process %d", evtPid)
+			}
+			_, err = target.addThread(debugInfo.Thread, int(debugEvent.ThreadId), false, flags&waitSuspendNewThreads != 0)
 			if err != nil {
-				return 0, 0, err
+				return 0, 0, 0, err
 			}
 			break
 		case _EXIT_THREAD_DEBUG_EVENT:
-			delete(dbp.threads, int(debugEvent.ThreadId))
+			if target := dbp.processByPID(evtPid); target != nil {
+				delete(target.threads, int(debugEvent.ThreadId))
+			}
 			break
 		case _OUTPUT_DEBUG_STRING_EVENT:
-			//TODO: Handle debug output strings
+			debugInfo := (*_OUTPUT_DEBUG_STRING_INFO)(unionPtr)
+			if target := dbp.processByPID(evtPid); target != nil {
+				if s, err := target.readDebugString(debugInfo); err == nil && s != "" {
+					target.os.mu.Lock()
+					target.os.debugStrings = append(target.os.debugStrings, s)
+					target.os.mu.Unlock()
+				}
+			}
 			break
 		case _LOAD_DLL_DEBUG_EVENT:
 			debugInfo := (*_LOAD_DLL_DEBUG_INFO)(unionPtr)
 			hFile := debugInfo.File
+			target := dbp.processByPID(evtPid)
+			var path string
+			var pathErr error
+			if target != nil {
+				path, pathErr = target.dllPath(debugInfo)
+			}
 			if hFile != 0 && hFile != syscall.InvalidHandle {
 				err = syscall.CloseHandle(hFile)
 				if err != nil {
-					return 0, 0, err
+					return 0, 0, 0, err
 				}
 			}
+			if target != nil && pathErr == nil && path != "" {
+				target.loadModule(path, uint64(debugInfo.BaseOfDll))
+			}
 			break
 		case _UNLOAD_DLL_DEBUG_EVENT:
+			debugInfo := (*_UNLOAD_DLL_DEBUG_INFO)(unionPtr)
+			if target := dbp.processByPID(evtPid); target != nil {
+				target.unloadModule(uint64(debugInfo.BaseOfDll))
+			}
 			break
 		case _RIP_EVENT:
 			break
 		case _EXCEPTION_DEBUG_EVENT:
 			exception := (*_EXCEPTION_DEBUG_INFO)(unionPtr)
+			target := dbp.processByPID(evtPid)
+			if target == nil {
+				return 0, 0, 0, fmt.Errorf("EXCEPTION_DEBUG_EVENT for untracked process %d", evtPid)
+			}
 			tid := int(debugEvent.ThreadId)
 
 			switch code := exception.ExceptionRecord.ExceptionCode; code {
@@ -329,9 +964,9 @@ func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, e
 				// it isn't we already removed that breakpoint and we can't deal with
 				// this exception anymore.
 				atbp := true
-				if thread, found := dbp.threads[tid]; found {
-					if data, err := thread.readMemory(exception.ExceptionRecord.ExceptionAddress, dbp.bi.arch.BreakpointSize()); err == nil {
-						instr := dbp.bi.arch.BreakpointInstruction()
+				if thread, found := target.threads[tid]; found {
+					if data, err := thread.readMemory(exception.ExceptionRecord.ExceptionAddress, target.bi.arch.BreakpointSize()); err == nil {
+						instr := target.bi.arch.BreakpointInstruction()
 						for i := range instr {
 							if data[i] != instr[i] {
 								atbp = false
@@ -345,42 +980,61 @@ func (dbp *Process) waitForDebugEvent(flags waitForDebugEventFlags) (threadID, e
 				}
 
 				if atbp {
-					dbp.os.breakThread = tid
-					return tid, 0, nil
+					target.os.breakThread = tid
+					return evtPid, tid, 0, nil
 				} else {
 					continueStatus = _DBG_CONTINUE
 				}
 			case _EXCEPTION_SINGLE_STEP:
-				dbp.os.breakThread = tid
-				return tid, 0, nil
+				target.os.breakThread = tid
+				return evtPid, tid, 0, nil
 			default:
+				firstChance := exception.FirstChance != 0
+				noncontinuable := exception.ExceptionRecord.ExceptionFlags&_EXCEPTION_NONCONTINUABLE != 0
+
+				target.os.mu.Lock()
+				mode := target.os.exceptionFilters[uint32(code)]
+				target.os.mu.Unlock()
+				stop := (firstChance && mode == ExceptionModeBreakFirstChance) ||
+					(!firstChance && mode == ExceptionModeBreakSecondChance) ||
+					(noncontinuable && mode != ExceptionModeIgnore)
+
+				if stop {
+					target.os.breakThread = tid
+					return evtPid, tid, 0, nil
+				}
 				continueStatus = _DBG_EXCEPTION_NOT_HANDLED
 			}
 		case _EXIT_PROCESS_DEBUG_EVENT:
 			debugInfo := (*_EXIT_PROCESS_DEBUG_INFO)(unionPtr)
+			if evtPid != dbp.pid {
+				// A followed child exited; the root target keeps running.
+				delete(dbp.os.children, evtPid)
+				break
+			}
 			exitCode = int(debugInfo.ExitCode)
 			shouldExit = true
 		default:
-			return 0, 0, fmt.Errorf("unknown debug event code: %d", debugEvent.DebugEventCode)
+			return 0, 0, 0, fmt.Errorf("unknown debug event code: %d", debugEvent.DebugEventCode)
 		}
 
 		// .. and then continue unless we received an event that indicated we should break into debugger.
 		err = _ContinueDebugEvent(debugEvent.ProcessId, debugEvent.ThreadId, continueStatus)
 		if err != nil {
-			return 0, 0, err
+			return 0, 0, 0, err
 		}
 
 		if shouldExit {
-			return 0, exitCode, nil
+			return dbp.pid, 0, exitCode, nil
 		}
 	}
 }
 
 func (dbp *Process) trapWait(pid int) (*Thread, error) {
 	var err error
-	var tid, exitCode int
+	var evtPid, tid, exitCode int
 	dbp.execPtraceFunc(func() {
-		tid, exitCode, err = dbp.waitForDebugEvent(waitBlocking)
+		evtPid, tid, exitCode, err = dbp.waitForDebugEvent(waitBlocking)
 	})
 	if err != nil {
 		return nil, err
@@ -389,7 +1043,14 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 		dbp.postExit()
 		return nil, ProcessExitedError{Pid: dbp.pid, Status: exitCode}
 	}
-	th := dbp.threads[tid]
+	target := dbp.processByPID(evtPid)
+	if target == nil {
+		return nil, fmt.Errorf("trapWait: event for untracked process %d", evtPid)
+	}
+	if err := dbp.initPendingChildren(); err != nil {
+		return nil, err
+	}
+	th := target.threads[tid]
 	return th, nil
 }
 
@@ -412,6 +1073,11 @@ func (dbp *Process) setCurrentBreakpoints(trapthread *Thread) error {
 	// call to _ContinueDebugEvent will resume execution of some of the
 	// target threads.
 
+	owner := dbp.processForThread(trapthread)
+	if owner == nil {
+		return fmt.Errorf("setCurrentBreakpoints: could not find owning process for thread %d", trapthread.ID)
+	}
+
 	err := trapthread.SetCurrentBreakpoint()
 	if err != nil {
 		return err
@@ -424,14 +1090,28 @@ func (dbp *Process) setCurrentBreakpoints(trapthread *Thread) error {
 			return err
 		}
 	}
+	for _, child := range dbp.os.children {
+		for _, thread := range child.threads {
+			thread.running = false
+			_, err := _SuspendThread(thread.os.hThread)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
+	// _ContinueDebugEvent must be given the PID/TID of the event that
+	// most recently stopped the target, which may be dbp or, with
+	// followed children, one of dbp.os.children — never dbp.pid itself
+	// unconditionally.
+	continuePid, continueTid := owner.pid, owner.os.breakThread
 	for {
 		var err error
-		var tid int
+		var evtPid, tid int
 		dbp.execPtraceFunc(func() {
-			err = _ContinueDebugEvent(uint32(dbp.pid), uint32(dbp.os.breakThread), _DBG_CONTINUE)
+			err = _ContinueDebugEvent(uint32(continuePid), uint32(continueTid), _DBG_CONTINUE)
 			if err == nil {
-				tid, _, _ = dbp.waitForDebugEvent(waitSuspendNewThreads)
+				evtPid, tid, _, _ = dbp.waitForDebugEvent(waitSuspendNewThreads)
 			}
 		})
 		if err != nil {
@@ -440,13 +1120,18 @@ func (dbp *Process) setCurrentBreakpoints(trapthread *Thread) error {
 		if tid == 0 {
 			break
 		}
-		err = dbp.threads[tid].SetCurrentBreakpoint()
+		target := dbp.processByPID(evtPid)
+		if target == nil {
+			return fmt.Errorf("setCurrentBreakpoints: event for untracked process %d", evtPid)
+		}
+		err = target.threads[tid].SetCurrentBreakpoint()
 		if err != nil {
 			return err
 		}
+		continuePid, continueTid = evtPid, tid
 	}
 
-	return nil
+	return dbp.initPendingChildren()
 }
 
 func (dbp *Process) exitGuard(err error) error {
@@ -454,26 +1139,47 @@ func (dbp *Process) exitGuard(err error) error {
 }
 
 func (dbp *Process) resume() error {
-	for _, thread := range dbp.threads {
-		if thread.CurrentBreakpoint != nil {
-			if err := thread.StepInstruction(); err != nil {
-				return err
+	threadGroups := make([][]*Thread, 0, 1+len(dbp.os.children))
+	threadGroups = append(threadGroups, threadSlice(dbp.threads))
+	for _, child := range dbp.os.children {
+		threadGroups = append(threadGroups, threadSlice(child.threads))
+	}
+
+	for _, threads := range threadGroups {
+		for _, thread := range threads {
+			if thread.CurrentBreakpoint != nil {
+				if err := thread.StepInstruction(); err != nil {
+					return err
+				}
+				thread.CurrentBreakpoint = nil
 			}
-			thread.CurrentBreakpoint = nil
 		}
 	}
 
-	for _, thread := range dbp.threads {
-		thread.running = true
-		_, err := _ResumeThread(thread.os.hThread)
-		if err != nil {
-			return err
+	for _, threads := range threadGroups {
+		for _, thread := range threads {
+			thread.running = true
+			_, err := _ResumeThread(thread.os.hThread)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// threadSlice flattens a Process's thread map into a slice, for callers
+// (like resume) that need to iterate dbp's own threads and each followed
+// child's threads uniformly.
+func threadSlice(threads map[int]*Thread) []*Thread {
+	s := make([]*Thread, 0, len(threads))
+	for _, thread := range threads {
+		s = append(s, thread)
+	}
+	return s
+}
+
 func (dbp *Process) detach(kill bool) error {
 	if !kill {
 		for _, thread := range dbp.threads {